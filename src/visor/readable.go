@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/skycoin/skycoin/src/cipher"
@@ -51,6 +52,10 @@ type TransactionStatus struct {
 	Height uint64 `json:"height"`
 	// Execute block seq
 	BlockSeq uint64 `json:"block_seq"`
+	// Hash of the block the txn was executed in, if confirmed
+	BlockHash cipher.SHA256 `json:"-"`
+	// Timestamp of the block the txn was executed in, if confirmed
+	BlockTime uint64 `json:"-"`
 }
 
 // NewUnconfirmedTransactionStatus creates unconfirmed transaction status
@@ -62,8 +67,10 @@ func NewUnconfirmedTransactionStatus() TransactionStatus {
 	}
 }
 
-// NewConfirmedTransactionStatus creates confirmed transaction status
-func NewConfirmedTransactionStatus(height uint64, blockSeq uint64) TransactionStatus {
+// NewConfirmedTransactionStatus creates confirmed transaction status.
+// blockHash and blockTime identify the block the txn was executed in, and
+// are surfaced as Blockhash/Blocktime on ReadableTransaction.
+func NewConfirmedTransactionStatus(height uint64, blockSeq uint64, blockHash cipher.SHA256, blockTime uint64) TransactionStatus {
 	if height == 0 {
 		logger.Panic("Invalid confirmed transaction height")
 	}
@@ -72,15 +79,175 @@ func NewConfirmedTransactionStatus(height uint64, blockSeq uint64) TransactionSt
 		Confirmed:   true,
 		Height:      height,
 		BlockSeq:    blockSeq,
+		BlockHash:   blockHash,
+		BlockTime:   blockTime,
 	}
 }
 
+// TxDecoratorFunc computes coin-specific data for a Transaction, to be
+// attached to its ReadableTransaction as CoinSpecificData
+type TxDecoratorFunc func(*Transaction) (interface{}, error)
+
+// OutputDecoratorFunc computes coin-specific data for a TransactionOutput, to
+// be attached to its ReadableTransactionOutput as CoinSpecificData
+type OutputDecoratorFunc func(*coin.TransactionOutput) (interface{}, error)
+
+// CoinSpecificDataDecoderFunc decodes a registered decorator's raw JSON back
+// into its typed Go value, for UnmarshalJSON on ReadableTransaction and
+// ReadableTransactionOutput
+type CoinSpecificDataDecoderFunc func(json.RawMessage) (interface{}, error)
+
+// TxDecoderFunc decodes a registered decorator's raw JSON back into its
+// typed Go value, for ReadableTransaction.UnmarshalJSON
+type TxDecoderFunc = CoinSpecificDataDecoderFunc
+
+// OutputDecoderFunc decodes a registered decorator's raw JSON back into its
+// typed Go value, for ReadableTransactionOutput.UnmarshalJSON
+type OutputDecoderFunc = CoinSpecificDataDecoderFunc
+
+var (
+	txDecoratorsMu sync.Mutex
+	txDecorators   = map[string]TxDecoratorFunc{}
+	txDecoders     = map[string]CoinSpecificDataDecoderFunc{}
+
+	outputDecoratorsMu sync.Mutex
+	outputDecorators   = map[string]OutputDecoratorFunc{}
+	outputDecoders     = map[string]CoinSpecificDataDecoderFunc{}
+)
+
+// RegisterTxDecorator registers fn under name to compute the CoinSpecificData
+// attached to ReadableTransaction by NewReadableTransaction. It is intended
+// to be called from the init() of a fiber-coin fork that reuses this
+// package. decoder, if non-nil, is used to decode the attached data back to
+// its typed Go value when unmarshaling JSON; if nil, the data decodes as the
+// generic value encoding/json produces for an interface{} (for a JSON object,
+// a map[string]interface{}).
+func RegisterTxDecorator(name string, fn TxDecoratorFunc, decoder TxDecoderFunc) {
+	txDecoratorsMu.Lock()
+	defer txDecoratorsMu.Unlock()
+	txDecorators[name] = fn
+	if decoder != nil {
+		txDecoders[name] = decoder
+	}
+}
+
+// RegisterOutputDecorator registers fn under name to compute the
+// CoinSpecificData attached to ReadableTransactionOutput by
+// NewReadableTransactionOutput. See RegisterTxDecorator.
+func RegisterOutputDecorator(name string, fn OutputDecoratorFunc, decoder OutputDecoderFunc) {
+	outputDecoratorsMu.Lock()
+	defer outputDecoratorsMu.Unlock()
+	outputDecorators[name] = fn
+	if decoder != nil {
+		outputDecoders[name] = decoder
+	}
+}
+
+func decorateTx(t *Transaction) (interface{}, error) {
+	txDecoratorsMu.Lock()
+	defer txDecoratorsMu.Unlock()
+
+	if len(txDecorators) == 0 {
+		return nil, nil
+	}
+
+	data := make(map[string]interface{}, len(txDecorators))
+	for name, fn := range txDecorators {
+		v, err := fn(t)
+		if err != nil {
+			return nil, fmt.Errorf("CoinSpecificData decorator %q failed: %v", name, err)
+		}
+		data[name] = v
+	}
+	return data, nil
+}
+
+func decorateOutput(t *coin.TransactionOutput) (interface{}, error) {
+	outputDecoratorsMu.Lock()
+	defer outputDecoratorsMu.Unlock()
+
+	if len(outputDecorators) == 0 {
+		return nil, nil
+	}
+
+	data := make(map[string]interface{}, len(outputDecorators))
+	for name, fn := range outputDecorators {
+		v, err := fn(t)
+		if err != nil {
+			return nil, fmt.Errorf("CoinSpecificData decorator %q failed: %v", name, err)
+		}
+		data[name] = v
+	}
+	return data, nil
+}
+
+// decodeCoinSpecificData decodes the raw coinSpecificData object, applying
+// any registered typed decoder to each named entry
+func decodeCoinSpecificData(raw json.RawMessage, decoders map[string]CoinSpecificDataDecoderFunc) (interface{}, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+
+	data := make(map[string]interface{}, len(fields))
+	for name, v := range fields {
+		if decode, ok := decoders[name]; ok {
+			decoded, err := decode(v)
+			if err != nil {
+				return nil, fmt.Errorf("CoinSpecificData decoder %q failed: %v", name, err)
+			}
+			data[name] = decoded
+			continue
+		}
+
+		var generic interface{}
+		if err := json.Unmarshal(v, &generic); err != nil {
+			return nil, err
+		}
+		data[name] = generic
+	}
+
+	return data, nil
+}
+
 // ReadableTransactionOutput readable transaction output
 type ReadableTransactionOutput struct {
-	Hash    string `json:"uxid"`
-	Address string `json:"dst"`
-	Coins   string `json:"coins"`
-	Hours   uint64 `json:"hours"`
+	Hash             string      `json:"uxid"`
+	Address          string      `json:"dst"`
+	Coins            string      `json:"coins"`
+	Hours            uint64      `json:"hours"`
+	CoinSpecificData interface{} `json:"coinSpecificData,omitempty" enc:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding CoinSpecificData
+// through any registered OutputDecoderFunc
+func (r *ReadableTransactionOutput) UnmarshalJSON(b []byte) error {
+	type readableTransactionOutput ReadableTransactionOutput
+	aux := struct {
+		CoinSpecificData json.RawMessage `json:"coinSpecificData,omitempty"`
+		*readableTransactionOutput
+	}{
+		readableTransactionOutput: (*readableTransactionOutput)(r),
+	}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.CoinSpecificData) == 0 {
+		return nil
+	}
+
+	outputDecoratorsMu.Lock()
+	decoders := outputDecoders
+	outputDecoratorsMu.Unlock()
+
+	data, err := decodeCoinSpecificData(aux.CoinSpecificData, decoders)
+	if err != nil {
+		return err
+	}
+	r.CoinSpecificData = data
+	return nil
 }
 
 // ReadableTransactionInput readable transaction input
@@ -99,11 +266,17 @@ func NewReadableTransactionOutput(t *coin.TransactionOutput, txid cipher.SHA256)
 		return nil, err
 	}
 
+	coinSpecificData, err := decorateOutput(t)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ReadableTransactionOutput{
-		Hash:    t.UxID(txid).Hex(),
-		Address: t.Address.String(),
-		Coins:   coinStr,
-		Hours:   t.Hours,
+		Hash:             t.UxID(txid).Hex(),
+		Address:          t.Address.String(),
+		Coins:            coinStr,
+		Hours:            t.Hours,
+		CoinSpecificData: coinSpecificData,
 	}, nil
 }
 
@@ -369,9 +542,70 @@ type ReadableTransaction struct {
 	Hash      string `json:"txid"`
 	InnerHash string `json:"inner_hash"`
 
+	// ValueOut is the sum of the coins of all outputs, as a decimal coin
+	// string (see droplet.ToString)
+	ValueOut string `json:"value_out,omitempty"`
+	// ValueIn is the sum of the coins of all inputs, as a decimal coin
+	// string (see droplet.ToString). Only
+	// populated when NewReadableTransaction is given an InputResolver.
+	ValueIn string `json:"value_in,omitempty"`
+	// Fees is the sum of the input hours minus the sum of the output hours.
+	// Only populated when NewReadableTransaction is given an InputResolver.
+	Fees uint64 `json:"fee,omitempty"`
+	// Confirmations is the number of blocks deep this txn is in the chain.
+	// 0 if unconfirmed.
+	Confirmations uint64 `json:"confirmations,omitempty"`
+	// Blockhash is the hash of the block this txn was executed in, if confirmed
+	Blockhash string `json:"block_hash,omitempty"`
+	// Blocktime is the timestamp of the block this txn was executed in, if confirmed
+	Blocktime uint64 `json:"block_time,omitempty"`
+
 	Sigs []string                    `json:"sigs"`
 	In   []string                    `json:"inputs"`
 	Out  []ReadableTransactionOutput `json:"outputs"`
+
+	// CoinSpecificData holds chain-specific metadata attached by any
+	// decorator registered with RegisterTxDecorator
+	CoinSpecificData interface{} `json:"coinSpecificData,omitempty" enc:"-"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, decoding CoinSpecificData
+// through any registered TxDecoderFunc
+func (r *ReadableTransaction) UnmarshalJSON(b []byte) error {
+	type readableTransaction ReadableTransaction
+	aux := struct {
+		CoinSpecificData json.RawMessage `json:"coinSpecificData,omitempty"`
+		*readableTransaction
+	}{
+		readableTransaction: (*readableTransaction)(r),
+	}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	if len(aux.CoinSpecificData) == 0 {
+		return nil
+	}
+
+	txDecoratorsMu.Lock()
+	decoders := txDecoders
+	txDecoratorsMu.Unlock()
+
+	data, err := decodeCoinSpecificData(aux.CoinSpecificData, decoders)
+	if err != nil {
+		return err
+	}
+	r.CoinSpecificData = data
+	return nil
+}
+
+// InputResolver resolves a transaction input's hash to the UxOut it spends.
+// It is implemented by types with access to the blockchain's unspent or
+// historical output data (e.g. the historian), and is passed to
+// NewReadableTransaction to populate ValueIn and Fees.
+type InputResolver interface {
+	GetUxOut(cipher.SHA256) (coin.UxOut, error)
 }
 
 // ReadableUnconfirmedTxn represents a readable unconfirmed transaction
@@ -383,11 +617,12 @@ type ReadableUnconfirmedTxn struct {
 	IsValid   bool                `json:"is_valid"`
 }
 
-// NewReadableUnconfirmedTxn creates a readable unconfirmed transaction
-func NewReadableUnconfirmedTxn(unconfirmed *UnconfirmedTxn) (*ReadableUnconfirmedTxn, error) {
+// NewReadableUnconfirmedTxn creates a readable unconfirmed transaction.
+// inputs is optional, see NewReadableTransaction.
+func NewReadableUnconfirmedTxn(unconfirmed *UnconfirmedTxn, inputs InputResolver) (*ReadableUnconfirmedTxn, error) {
 	tx, err := NewReadableTransaction(&Transaction{
 		Txn: unconfirmed.Txn,
-	})
+	}, inputs)
 	if err != nil {
 		return nil, err
 	}
@@ -400,11 +635,12 @@ func NewReadableUnconfirmedTxn(unconfirmed *UnconfirmedTxn) (*ReadableUnconfirme
 	}, nil
 }
 
-// NewReadableUnconfirmedTxns converts []UnconfirmedTxn to []ReadableUnconfirmedTxn
-func NewReadableUnconfirmedTxns(txs []UnconfirmedTxn) ([]ReadableUnconfirmedTxn, error) {
+// NewReadableUnconfirmedTxns converts []UnconfirmedTxn to []ReadableUnconfirmedTxn.
+// inputs is optional, see NewReadableTransaction.
+func NewReadableUnconfirmedTxns(txs []UnconfirmedTxn, inputs InputResolver) ([]ReadableUnconfirmedTxn, error) {
 	rut := make([]ReadableUnconfirmedTxn, len(txs))
 	for i := range txs {
-		tx, err := NewReadableUnconfirmedTxn(&txs[i])
+		tx, err := NewReadableUnconfirmedTxn(&txs[i], inputs)
 		if err != nil {
 			return []ReadableUnconfirmedTxn{}, err
 		}
@@ -413,8 +649,12 @@ func NewReadableUnconfirmedTxns(txs []UnconfirmedTxn) ([]ReadableUnconfirmedTxn,
 	return rut, nil
 }
 
-// NewReadableTransaction creates a readable transaction
-func NewReadableTransaction(t *Transaction) (*ReadableTransaction, error) {
+// NewReadableTransaction creates a readable transaction. inputs is optional;
+// when provided, it is used to resolve the coins and hours of each input so
+// that ValueIn and Fees can be computed. When inputs is nil, ValueIn and
+// Fees are left unset, preserving the lightweight behavior of callers
+// without access to a resolver.
+func NewReadableTransaction(t *Transaction, inputs InputResolver) (*ReadableTransaction, error) {
 	if t.Status.BlockSeq != 0 && t.Status.Confirmed && len(t.Txn.In) == 0 {
 		return nil, errors.New("NewReadableTransaction: Confirmed transaction Status.BlockSeq != 0 but Txn.In is empty")
 	}
@@ -436,6 +676,7 @@ func NewReadableTransaction(t *Transaction) (*ReadableTransaction, error) {
 	}
 
 	out := make([]ReadableTransactionOutput, len(t.Txn.Out))
+	var outCoins, outHours uint64
 	for i := range t.Txn.Out {
 		o, err := NewReadableTransactionOutput(&t.Txn.Out[i], txid)
 		if err != nil {
@@ -443,6 +684,87 @@ func NewReadableTransaction(t *Transaction) (*ReadableTransaction, error) {
 		}
 
 		out[i] = *o
+
+		outCoins, err = coin.AddUint64(outCoins, t.Txn.Out[i].Coins)
+		if err != nil {
+			return nil, err
+		}
+		outHours, err = coin.AddUint64(outHours, t.Txn.Out[i].Hours)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	valueOut, err := droplet.ToString(outCoins)
+	if err != nil {
+		return nil, err
+	}
+
+	var valueIn string
+	var fees uint64
+	if inputs != nil {
+		// Hours accrue with elapsed time, so the coins-hours an input is
+		// worth at spend time comes from ux.CoinHours, not the Body.Hours
+		// snapshot taken when it was created (see NewReadableTransactionInput).
+		calculateHoursTime := t.Time
+		if t.Status.Confirmed {
+			calculateHoursTime = t.Status.BlockTime
+		}
+
+		var inCoins, inHours uint64
+		for i := range t.Txn.In {
+			ux, err := inputs.GetUxOut(t.Txn.In[i])
+			if err != nil {
+				return nil, fmt.Errorf("NewReadableTransaction: inputs.GetUxOut failed: %v", err)
+			}
+
+			inCoins, err = coin.AddUint64(inCoins, ux.Body.Coins)
+			if err != nil {
+				return nil, err
+			}
+
+			// The overflow bug causes this to fail for some transactions, allow it to pass
+			calculatedHours, err := ux.CoinHours(calculateHoursTime)
+			if err != nil {
+				logger.Critical().Warningf("NewReadableTransaction: ignoring ux.CoinHours failure: %v", err)
+				calculatedHours = 0
+			}
+			inHours, err = coin.AddUint64(inHours, calculatedHours)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		valueIn, err = droplet.ToString(inCoins)
+		if err != nil {
+			return nil, err
+		}
+
+		if inHours >= outHours {
+			fees = inHours - outHours
+		}
+	}
+
+	// Confirmations reuses TransactionStatus.Height, the existing chain-depth
+	// value ("how many blocks deep... at least 1 if confirmed"), rather than
+	// taking a separate head sequence that a caller could pass inconsistently.
+	var confirmations uint64
+	if t.Status.Confirmed {
+		confirmations = t.Status.Height
+	}
+
+	// BlockHash is only meaningful once set by a confirmed-status constructor
+	// (e.g. NewReadableBlockBody, NewConfirmedTransactionStatus); guard
+	// against a caller setting Confirmed without it so a zero-value hash is
+	// never surfaced as if it were real.
+	var blockhash string
+	if t.Status.Confirmed && t.Status.BlockHash != (cipher.SHA256{}) {
+		blockhash = t.Status.BlockHash.Hex()
+	}
+
+	coinSpecificData, err := decorateTx(t)
+	if err != nil {
+		return nil, err
 	}
 
 	return &ReadableTransaction{
@@ -452,9 +774,18 @@ func NewReadableTransaction(t *Transaction) (*ReadableTransaction, error) {
 		InnerHash: t.Txn.InnerHash.Hex(),
 		Timestamp: t.Time,
 
+		ValueOut:      valueOut,
+		ValueIn:       valueIn,
+		Fees:          fees,
+		Confirmations: confirmations,
+		Blockhash:     blockhash,
+		Blocktime:     t.Status.BlockTime,
+
 		Sigs: sigs,
 		In:   in,
 		Out:  out,
+
+		CoinSpecificData: coinSpecificData,
 	}, nil
 }
 
@@ -467,9 +798,15 @@ type ReadableBlockHeader struct {
 	Fee               uint64 `json:"fee"`
 	Version           uint32 `json:"version"`
 	BodyHash          string `json:"tx_body_hash"`
+	// WithdrawalsHash is the Merkle root of the block's withdrawal list.
+	// Omitted for blocks before WithdrawalsActivationSeq.
+	WithdrawalsHash string `json:"withdrawals_hash,omitempty"`
 }
 
-// NewReadableBlockHeader creates a readable block header
+// NewReadableBlockHeader creates a readable block header. WithdrawalsHash is
+// left unset; coin.Block does not carry a withdrawal list in this tree, so
+// NewReadableBlock fills it in itself from the withdrawals it is given. See
+// NewReadableBlock.
 func NewReadableBlockHeader(b *coin.BlockHeader) ReadableBlockHeader {
 	return ReadableBlockHeader{
 		BkSeq:             b.BkSeq,
@@ -485,10 +822,23 @@ func NewReadableBlockHeader(b *coin.BlockHeader) ReadableBlockHeader {
 // ReadableBlockBody represents a readable block body
 type ReadableBlockBody struct {
 	Transactions []ReadableTransaction `json:"txns"`
+	// Withdrawals are the block-level credits minted in this block, if any.
+	// Empty for blocks before WithdrawalsActivationSeq.
+	Withdrawals []ReadableWithdrawal `json:"withdrawals,omitempty"`
 }
 
-// NewReadableBlockBody creates a readable block body
-func NewReadableBlockBody(b *coin.Block) (*ReadableBlockBody, error) {
+// NewReadableBlockBody creates a readable block body. headSeq is the
+// current chain head's sequence, used to compute each txn's Confirmations
+// ("how many blocks deep... at least 1 if confirmed"); it must be >= b.Seq().
+// inputs is optional, see NewReadableTransaction. withdrawals are the
+// block-level credits executed alongside b, if any; coin.Block does not
+// carry them in this tree, so the caller that executed them (e.g. with
+// ExecuteWithdrawals) must pass them in directly.
+func NewReadableBlockBody(b *coin.Block, headSeq uint64, inputs InputResolver, withdrawals []Withdrawal) (*ReadableBlockBody, error) {
+	if headSeq < b.Seq() {
+		return nil, fmt.Errorf("NewReadableBlockBody: headSeq %d is behind block seq %d", headSeq, b.Seq())
+	}
+
 	txns := make([]ReadableTransaction, len(b.Body.Transactions))
 	for i := range b.Body.Transactions {
 		t := Transaction{
@@ -496,18 +846,27 @@ func NewReadableBlockBody(b *coin.Block) (*ReadableBlockBody, error) {
 			Status: TransactionStatus{
 				BlockSeq:  b.Seq(),
 				Confirmed: true,
+				Height:    headSeq - b.Seq() + 1,
+				BlockHash: b.Head.Hash(),
+				BlockTime: b.Head.Time,
 			},
 		}
 
-		tx, err := NewReadableTransaction(&t)
+		tx, err := NewReadableTransaction(&t, inputs)
 		if err != nil {
 			return nil, err
 		}
 		txns[i] = *tx
 	}
 
+	rws, err := NewReadableWithdrawals(withdrawals)
+	if err != nil {
+		return nil, err
+	}
+
 	return &ReadableBlockBody{
 		Transactions: txns,
+		Withdrawals:  rws,
 	}, nil
 }
 
@@ -518,14 +877,24 @@ type ReadableBlock struct {
 	Size int                 `json:"size"`
 }
 
-// NewReadableBlock creates a readable block
-func NewReadableBlock(b *coin.Block) (*ReadableBlock, error) {
-	body, err := NewReadableBlockBody(b)
+// NewReadableBlock creates a readable block. headSeq is the current chain
+// head's sequence, see NewReadableBlockBody. inputs is optional, see
+// NewReadableTransaction. withdrawals is optional, see NewReadableBlockBody;
+// when non-empty and b.Seq() is at or past WithdrawalsActivationSeq, its
+// Merkle root is set as Head.WithdrawalsHash.
+func NewReadableBlock(b *coin.Block, headSeq uint64, inputs InputResolver, withdrawals []Withdrawal) (*ReadableBlock, error) {
+	body, err := NewReadableBlockBody(b, headSeq, inputs, withdrawals)
 	if err != nil {
 		return nil, err
 	}
+
+	head := NewReadableBlockHeader(&b.Head)
+	if b.Seq() >= WithdrawalsActivationSeq && len(withdrawals) > 0 {
+		head.WithdrawalsHash = WithdrawalsHash(withdrawals).Hex()
+	}
+
 	return &ReadableBlock{
-		Head: NewReadableBlockHeader(&b.Head),
+		Head: head,
 		Body: *body,
 		Size: b.Size(),
 	}, nil
@@ -536,11 +905,16 @@ type ReadableBlocks struct {
 	Blocks []ReadableBlock `json:"blocks"`
 }
 
-// NewReadableBlocks converts []coin.SignedBlock to ReadableBlocks
-func NewReadableBlocks(blocks []coin.SignedBlock) (*ReadableBlocks, error) {
+// NewReadableBlocks converts []coin.SignedBlock to ReadableBlocks. headSeq
+// is the current chain head's sequence, see NewReadableBlockBody. inputs is
+// optional, see NewReadableTransaction. coin.SignedBlock does not carry a
+// withdrawal list in this tree, so blocks converted through here never have
+// withdrawals attached; callers that need withdrawals reflected should build
+// each ReadableBlock individually with NewReadableBlock instead.
+func NewReadableBlocks(blocks []coin.SignedBlock, headSeq uint64, inputs InputResolver) (*ReadableBlocks, error) {
 	rbs := make([]ReadableBlock, 0, len(blocks))
 	for _, b := range blocks {
-		rb, err := NewReadableBlock(&b.Block)
+		rb, err := NewReadableBlock(&b.Block, headSeq, inputs, nil)
 		if err != nil {
 			return nil, err
 		}
@@ -0,0 +1,324 @@
+package visor
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
+)
+
+// MarshalReadableBlock encodes a ReadableBlock using the project's encoder
+// package, producing a binary representation of the block that omits JSON's
+// field names and punctuation. Hashes, addresses and coin amounts are still
+// encoded as the same hex, base58 and decimal strings the JSON carries. It is
+// an alternative to the canonical JSON wire format for bulk export/import.
+//
+// CoinSpecificData attached by a registered TxDecorator/OutputDecorator is
+// not carried through this format, since the encoder package requires
+// concrete field types; callers that need it should use the JSON API.
+func MarshalReadableBlock(rb *ReadableBlock) ([]byte, error) {
+	return encoder.Serialize(rb), nil
+}
+
+// UnmarshalReadableBlock decodes a ReadableBlock previously encoded by
+// MarshalReadableBlock
+func UnmarshalReadableBlock(b []byte) (*ReadableBlock, error) {
+	var rb ReadableBlock
+	if err := encoder.DeserializeRaw(b, &rb); err != nil {
+		return nil, fmt.Errorf("UnmarshalReadableBlock failed: %v", err)
+	}
+	return &rb, nil
+}
+
+// VerifyReadableBlock reconstructs the coin.BlockHeader and each
+// coin.Transaction from the decoded ReadableBlock and recomputes their
+// hashes, confirming they match Head.BlockHash and each txn's txid. This
+// catches corruption introduced by the binary round-trip; it does not
+// re-verify transaction signatures or chain linkage.
+func VerifyReadableBlock(rb *ReadableBlock) error {
+	header, err := readableBlockHeaderToBlockHeader(&rb.Head)
+	if err != nil {
+		return fmt.Errorf("VerifyReadableBlock: decoding header failed: %v", err)
+	}
+
+	if blockHash := header.Hash().Hex(); blockHash != rb.Head.BlockHash {
+		return fmt.Errorf("VerifyReadableBlock: decoded block hash %s does not match header BlockHash %s", blockHash, rb.Head.BlockHash)
+	}
+
+	if err := verifyReadableWithdrawalsHash(rb); err != nil {
+		return err
+	}
+
+	for i := range rb.Body.Transactions {
+		rt := &rb.Body.Transactions[i]
+
+		txn, err := readableTransactionToTransaction(rt)
+		if err != nil {
+			return fmt.Errorf("VerifyReadableBlock: decoding txn %d failed: %v", i, err)
+		}
+
+		if txid := txn.TxIDHex(); txid != rt.Hash {
+			return fmt.Errorf("VerifyReadableBlock: decoded txn %d hash %s does not match txid %s", i, txid, rt.Hash)
+		}
+	}
+
+	return nil
+}
+
+// readableBlockHeaderToBlockHeader reconstructs a coin.BlockHeader from its
+// readable form, decoding hex-encoded hashes back to cipher.SHA256
+func readableBlockHeaderToBlockHeader(rh *ReadableBlockHeader) (*coin.BlockHeader, error) {
+	prevHash, err := cipher.SHA256FromHex(rh.PreviousBlockHash)
+	if err != nil {
+		return nil, fmt.Errorf("PreviousBlockHash is invalid: %v", err)
+	}
+
+	bodyHash, err := cipher.SHA256FromHex(rh.BodyHash)
+	if err != nil {
+		return nil, fmt.Errorf("BodyHash is invalid: %v", err)
+	}
+
+	return &coin.BlockHeader{
+		BkSeq:    rh.BkSeq,
+		PrevHash: prevHash,
+		Time:     rh.Time,
+		Fee:      rh.Fee,
+		Version:  rh.Version,
+		BodyHash: bodyHash,
+	}, nil
+}
+
+// verifyReadableWithdrawalsHash recomputes the Merkle root of rb.Body.Withdrawals
+// and confirms it matches rb.Head.WithdrawalsHash. coin.BlockHeader does not
+// carry a WithdrawalsHash field in this tree, so this is checked independently
+// of readableBlockHeaderToBlockHeader/header.Hash() rather than folded into it.
+func verifyReadableWithdrawalsHash(rb *ReadableBlock) error {
+	ws := make([]Withdrawal, len(rb.Body.Withdrawals))
+	for i, rw := range rb.Body.Withdrawals {
+		addr, err := cipher.DecodeBase58Address(rw.Address)
+		if err != nil {
+			return fmt.Errorf("VerifyReadableBlock: withdrawal %d address is invalid: %v", i, err)
+		}
+		coins, err := droplet.FromString(rw.Coins)
+		if err != nil {
+			return fmt.Errorf("VerifyReadableBlock: withdrawal %d coins is invalid: %v", i, err)
+		}
+		ws[i] = Withdrawal{
+			Index:   rw.Index,
+			Address: addr,
+			Coins:   coins,
+			Hours:   rw.Hours,
+		}
+	}
+
+	var withdrawalsHash string
+	if len(ws) > 0 {
+		withdrawalsHash = WithdrawalsHash(ws).Hex()
+	}
+	if withdrawalsHash != rb.Head.WithdrawalsHash {
+		return fmt.Errorf("VerifyReadableBlock: decoded withdrawals hash %s does not match header WithdrawalsHash %s", withdrawalsHash, rb.Head.WithdrawalsHash)
+	}
+
+	return nil
+}
+
+// readableTransactionToTransaction reconstructs a coin.Transaction from its
+// readable form, decoding hex/droplet-encoded fields back to their binary
+// representation
+func readableTransactionToTransaction(rt *ReadableTransaction) (*coin.Transaction, error) {
+	sigs := make([]cipher.Sig, len(rt.Sigs))
+	for i, s := range rt.Sigs {
+		sig, err := cipher.SigFromHex(s)
+		if err != nil {
+			return nil, fmt.Errorf("sig %d is invalid: %v", i, err)
+		}
+		sigs[i] = sig
+	}
+
+	in := make([]cipher.SHA256, len(rt.In))
+	for i, h := range rt.In {
+		hash, err := cipher.SHA256FromHex(h)
+		if err != nil {
+			return nil, fmt.Errorf("input %d is invalid: %v", i, err)
+		}
+		in[i] = hash
+	}
+
+	out := make([]coin.TransactionOutput, len(rt.Out))
+	for i, o := range rt.Out {
+		addr, err := cipher.DecodeBase58Address(o.Address)
+		if err != nil {
+			return nil, fmt.Errorf("output %d address is invalid: %v", i, err)
+		}
+		coins, err := droplet.FromString(o.Coins)
+		if err != nil {
+			return nil, fmt.Errorf("output %d coins is invalid: %v", i, err)
+		}
+		out[i] = coin.TransactionOutput{
+			Address: addr,
+			Coins:   coins,
+			Hours:   o.Hours,
+		}
+	}
+
+	innerHash, err := cipher.SHA256FromHex(rt.InnerHash)
+	if err != nil {
+		return nil, fmt.Errorf("InnerHash is invalid: %v", err)
+	}
+
+	return &coin.Transaction{
+		Length:    rt.Length,
+		Type:      rt.Type,
+		InnerHash: innerHash,
+		Sigs:      sigs,
+		In:        in,
+		Out:       out,
+	}, nil
+}
+
+// CompressionFlag identifies the per-frame compression applied by
+// ReadableBlockWriter/ReadableBlockReader
+type CompressionFlag byte
+
+const (
+	// CompressionNone indicates a frame's payload is not compressed
+	CompressionNone CompressionFlag = iota
+	// CompressionSnappy indicates a frame's payload is snappy-compressed
+	CompressionSnappy
+	// CompressionZstd indicates a frame's payload is zstd-compressed
+	CompressionZstd
+)
+
+// Compressor compresses and decompresses frame payloads for
+// ReadableBlockWriter/ReadableBlockReader. skycoin does not vendor a
+// snappy/zstd implementation itself; callers that want CompressionSnappy or
+// CompressionZstd register a Compressor backed by their library of choice.
+type Compressor interface {
+	Compress([]byte) ([]byte, error)
+	Decompress([]byte) ([]byte, error)
+}
+
+var (
+	compressorsMu sync.Mutex
+	compressors   = map[CompressionFlag]Compressor{}
+)
+
+// RegisterCompressor registers c to handle the given compression flag for
+// ReadableBlockWriter/ReadableBlockReader. It is intended to be called from
+// the init() of whichever package wires in a snappy or zstd implementation.
+func RegisterCompressor(flag CompressionFlag, c Compressor) {
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+	compressors[flag] = c
+}
+
+func getCompressor(flag CompressionFlag) (Compressor, error) {
+	if flag == CompressionNone {
+		return nil, nil
+	}
+
+	compressorsMu.Lock()
+	defer compressorsMu.Unlock()
+
+	c, ok := compressors[flag]
+	if !ok {
+		return nil, fmt.Errorf("no Compressor registered for CompressionFlag %d", flag)
+	}
+	return c, nil
+}
+
+// ReadableBlockWriter writes a stream of length-prefixed, optionally
+// compressed ReadableBlock frames to an underlying io.Writer, to be read back
+// with ReadableBlockReader. No HTTP endpoint or CLI command in this tree
+// serves or consumes this stream.
+type ReadableBlockWriter struct {
+	w io.Writer
+}
+
+// NewReadableBlockWriter creates a ReadableBlockWriter
+func NewReadableBlockWriter(w io.Writer) *ReadableBlockWriter {
+	return &ReadableBlockWriter{w: w}
+}
+
+// WriteBlock writes rb as a single frame, compressed according to flag
+func (bw *ReadableBlockWriter) WriteBlock(rb *ReadableBlock, flag CompressionFlag) error {
+	payload, err := MarshalReadableBlock(rb)
+	if err != nil {
+		return err
+	}
+
+	if flag != CompressionNone {
+		c, err := getCompressor(flag)
+		if err != nil {
+			return err
+		}
+		payload, err = c.Compress(payload)
+		if err != nil {
+			return fmt.Errorf("ReadableBlockWriter: compress failed: %v", err)
+		}
+	}
+
+	header := make([]byte, 5)
+	header[0] = byte(flag)
+	binary.BigEndian.PutUint32(header[1:], uint32(len(payload)))
+
+	if _, err := bw.w.Write(header); err != nil {
+		return err
+	}
+	_, err = bw.w.Write(payload)
+	return err
+}
+
+// maxFrameSize bounds the payload length ReadBlock will allocate for a
+// single frame, so a corrupt or malicious stream claiming an implausibly
+// large frame cannot force an equally large allocation per call.
+const maxFrameSize = 64 * 1024 * 1024
+
+// ReadableBlockReader reads a stream of frames written by
+// ReadableBlockWriter
+type ReadableBlockReader struct {
+	r io.Reader
+}
+
+// NewReadableBlockReader creates a ReadableBlockReader
+func NewReadableBlockReader(r io.Reader) *ReadableBlockReader {
+	return &ReadableBlockReader{r: r}
+}
+
+// ReadBlock reads and decodes the next frame. It returns io.EOF when the
+// stream is exhausted.
+func (br *ReadableBlockReader) ReadBlock() (*ReadableBlock, error) {
+	header := make([]byte, 5)
+	if _, err := io.ReadFull(br.r, header); err != nil {
+		return nil, err
+	}
+
+	flag := CompressionFlag(header[0])
+	length := binary.BigEndian.Uint32(header[1:])
+	if length > maxFrameSize {
+		return nil, fmt.Errorf("ReadableBlockReader: frame size %d exceeds maxFrameSize %d", length, maxFrameSize)
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(br.r, payload); err != nil {
+		return nil, fmt.Errorf("ReadableBlockReader: short frame: %v", err)
+	}
+
+	if flag != CompressionNone {
+		c, err := getCompressor(flag)
+		if err != nil {
+			return nil, err
+		}
+		payload, err = c.Decompress(payload)
+		if err != nil {
+			return nil, fmt.Errorf("ReadableBlockReader: decompress failed: %v", err)
+		}
+	}
+
+	return UnmarshalReadableBlock(payload)
+}
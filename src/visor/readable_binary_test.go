@@ -0,0 +1,82 @@
+package visor
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+func makeTestReadableBlock(t *testing.T) *ReadableBlock {
+	block := &coin.Block{
+		Head: coin.BlockHeader{BkSeq: 10, Time: 1600000000},
+		Body: coin.BlockBody{
+			Transactions: []coin.Transaction{
+				{
+					In: []cipher.SHA256{cipher.SumSHA256([]byte("input"))},
+					Out: []coin.TransactionOutput{
+						{Coins: 1e6, Hours: 10},
+					},
+				},
+			},
+		},
+	}
+
+	rb, err := NewReadableBlock(block, 10, nil, nil)
+	require.NoError(t, err)
+	return rb
+}
+
+// TestMarshalUnmarshalVerifyReadableBlock checks that a ReadableBlock
+// survives a MarshalReadableBlock/UnmarshalReadableBlock round trip intact,
+// and that the round-tripped block passes VerifyReadableBlock.
+func TestMarshalUnmarshalVerifyReadableBlock(t *testing.T) {
+	rb := makeTestReadableBlock(t)
+
+	b, err := MarshalReadableBlock(rb)
+	require.NoError(t, err)
+
+	got, err := UnmarshalReadableBlock(b)
+	require.NoError(t, err)
+
+	assert.Equal(t, rb, got)
+	assert.NoError(t, VerifyReadableBlock(got))
+}
+
+// TestReadableBlockWriterReaderRoundTrip checks that WriteBlock/ReadBlock
+// round-trip a ReadableBlock through an uncompressed frame stream, and that
+// ReadBlock reports io.EOF once the stream is exhausted.
+func TestReadableBlockWriterReaderRoundTrip(t *testing.T) {
+	rb := makeTestReadableBlock(t)
+
+	var buf bytes.Buffer
+	w := NewReadableBlockWriter(&buf)
+	require.NoError(t, w.WriteBlock(rb, CompressionNone))
+
+	r := NewReadableBlockReader(&buf)
+	got, err := r.ReadBlock()
+	require.NoError(t, err)
+	assert.Equal(t, rb, got)
+
+	_, err = r.ReadBlock()
+	assert.Equal(t, io.EOF, err)
+}
+
+// TestReadableBlockReaderRejectsOversizedFrame checks that ReadBlock refuses
+// to allocate a frame whose declared length exceeds maxFrameSize, rather
+// than trusting an attacker-controlled length prefix.
+func TestReadableBlockReaderRejectsOversizedFrame(t *testing.T) {
+	header := make([]byte, 5)
+	header[0] = byte(CompressionNone)
+	binary.BigEndian.PutUint32(header[1:], maxFrameSize+1)
+
+	buf := bytes.NewBuffer(header)
+	_, err := NewReadableBlockReader(buf).ReadBlock()
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package visor
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/coin"
+)
+
+type fakeInputResolver struct {
+	uxouts map[cipher.SHA256]coin.UxOut
+}
+
+func (f *fakeInputResolver) GetUxOut(h cipher.SHA256) (coin.UxOut, error) {
+	ux, ok := f.uxouts[h]
+	if !ok {
+		return coin.UxOut{}, errors.New("fakeInputResolver: no such UxOut")
+	}
+	return ux, nil
+}
+
+// TestNewReadableBlockBodyConfirmationsAndFees checks that transactions
+// converted through NewReadableBlockBody get a real, nonzero Confirmations
+// derived from headSeq, and that Fees is computed from the coin-hours an
+// input earns by block time rather than its Body.Hours snapshot.
+func TestNewReadableBlockBodyConfirmationsAndFees(t *testing.T) {
+	const bkSeq = uint64(100)
+	const headSeq = uint64(105)
+	const blockTime = uint64(1600000000)
+
+	ux := coin.UxOut{
+		Head: coin.UxHead{
+			Time:  blockTime - 1000,
+			BkSeq: bkSeq - 1,
+		},
+		Body: coin.UxBody{
+			Address: cipher.Address{},
+			Coins:   10e6,
+			Hours:   100,
+		},
+	}
+
+	inHours, err := ux.CoinHours(blockTime)
+	require.NoError(t, err)
+
+	outHours := inHours / 2
+	txn := coin.Transaction{
+		In: []cipher.SHA256{ux.Hash()},
+		Out: []coin.TransactionOutput{
+			{Address: cipher.Address{}, Coins: ux.Body.Coins, Hours: outHours},
+		},
+	}
+
+	block := &coin.Block{
+		Head: coin.BlockHeader{BkSeq: bkSeq, Time: blockTime},
+		Body: coin.BlockBody{Transactions: []coin.Transaction{txn}},
+	}
+
+	inputs := &fakeInputResolver{uxouts: map[cipher.SHA256]coin.UxOut{
+		ux.Hash(): ux,
+	}}
+
+	body, err := NewReadableBlockBody(block, headSeq, inputs, nil)
+	require.NoError(t, err)
+	require.Len(t, body.Transactions, 1)
+
+	rt := body.Transactions[0]
+	assert.Equal(t, headSeq-bkSeq+1, rt.Confirmations)
+	assert.Equal(t, inHours-outHours, rt.Fees)
+}
+
+// TestNewReadableBlockBodyHeadSeqBehindBlock checks that a headSeq older
+// than the block being converted is rejected rather than silently producing
+// an underflowed Confirmations.
+func TestNewReadableBlockBodyHeadSeqBehindBlock(t *testing.T) {
+	block := &coin.Block{
+		Head: coin.BlockHeader{BkSeq: 100},
+	}
+
+	_, err := NewReadableBlockBody(block, 99, nil, nil)
+	require.Error(t, err)
+}
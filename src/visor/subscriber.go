@@ -0,0 +1,297 @@
+package visor
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/wallet"
+)
+
+// SubscriberBufferSize is the number of events buffered per subscription
+// before the oldest queued event is dropped to make room for the newest one
+const SubscriberBufferSize = 64
+
+// CancelFunc unregisters a subscription. It is safe to call more than once.
+type CancelFunc func()
+
+// Direction describes whether a transaction moved coins into or out of a
+// subscribed address
+type Direction string
+
+const (
+	// DirectionIncoming is set on an AddressEvent when the subscribed address
+	// receives coins
+	DirectionIncoming Direction = "incoming"
+	// DirectionOutgoing is set on an AddressEvent when the subscribed address
+	// spends coins
+	DirectionOutgoing Direction = "outgoing"
+)
+
+// AddressEvent is published to an address subscription whenever a
+// transaction touching that address is seen, confirmed or unconfirmed
+type AddressEvent struct {
+	Txn       ReadableTransaction `json:"transaction"`
+	Direction Direction           `json:"direction"`
+	Balance   wallet.Balance      `json:"balance"`
+}
+
+// Subscriber fans out readable blocks, unconfirmed transactions and
+// per-address events to registered subscribers. It is intended to be driven
+// by Visor.ExecuteSignedBlock and the unconfirmed pool's insert/remove paths
+// calling the Notify* methods as their respective events occur, with the
+// HTTP gateway's websocket endpoint registering subscriptions through the
+// Subscribe* methods and streaming their channels out to clients; wiring
+// those call sites in is left to a follow-up change.
+//
+// Each subscription has a bounded buffer: if a subscriber falls behind, the
+// oldest buffered event is dropped to make room for the newest one, and its
+// dropped counter (see Dropped) is incremented, so a slow client cannot
+// block consensus.
+type Subscriber struct {
+	mu     sync.Mutex
+	nextID uint64
+
+	blockSubs       map[uint64]*blockSubscription
+	unconfirmedSubs map[uint64]*unconfirmedSubscription
+	addressSubs     map[uint64]*addressSubscription
+}
+
+// NewSubscriber creates a Subscriber
+func NewSubscriber() *Subscriber {
+	return &Subscriber{
+		blockSubs:       make(map[uint64]*blockSubscription),
+		unconfirmedSubs: make(map[uint64]*unconfirmedSubscription),
+		addressSubs:     make(map[uint64]*addressSubscription),
+	}
+}
+
+type blockSubscription struct {
+	fromSeq uint64
+	ch      chan ReadableBlock
+	dropped uint64
+}
+
+type unconfirmedSubscription struct {
+	ch      chan ReadableUnconfirmedTxn
+	dropped uint64
+}
+
+type addressSubscription struct {
+	addr    cipher.Address
+	ch      chan AddressEvent
+	dropped uint64
+}
+
+// Dropped returns the number of events dropped from this subscription so far
+// because the subscriber fell behind. It is safe to call concurrently with
+// delivery.
+type Dropped func() uint64
+
+// SubscribeBlocks registers a subscription that receives every block
+// executed from fromSeq onwards. It does not replay blocks already in the
+// chain; callers that need backlog should read historical blocks separately
+// before relying on this subscription for new ones.
+func (s *Subscriber) SubscribeBlocks(fromSeq uint64) (<-chan ReadableBlock, Dropped, CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+
+	sub := &blockSubscription{
+		fromSeq: fromSeq,
+		ch:      make(chan ReadableBlock, SubscriberBufferSize),
+	}
+	s.blockSubs[id] = sub
+
+	dropped := func() uint64 { return atomic.LoadUint64(&sub.dropped) }
+
+	return sub.ch, dropped, s.cancelFunc(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.blockSubs, id)
+	})
+}
+
+// SubscribeUnconfirmed registers a subscription that receives every
+// transaction inserted into the unconfirmed pool
+func (s *Subscriber) SubscribeUnconfirmed() (<-chan ReadableUnconfirmedTxn, Dropped, CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+
+	sub := &unconfirmedSubscription{
+		ch: make(chan ReadableUnconfirmedTxn, SubscriberBufferSize),
+	}
+	s.unconfirmedSubs[id] = sub
+
+	dropped := func() uint64 { return atomic.LoadUint64(&sub.dropped) }
+
+	return sub.ch, dropped, s.cancelFunc(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.unconfirmedSubs, id)
+	})
+}
+
+// SubscribeAddress registers a subscription that receives an AddressEvent
+// whenever a confirmed or unconfirmed transaction credits or debits addr
+func (s *Subscriber) SubscribeAddress(addr cipher.Address) (<-chan AddressEvent, Dropped, CancelFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := s.nextID
+	s.nextID++
+
+	sub := &addressSubscription{
+		addr: addr,
+		ch:   make(chan AddressEvent, SubscriberBufferSize),
+	}
+	s.addressSubs[id] = sub
+
+	dropped := func() uint64 { return atomic.LoadUint64(&sub.dropped) }
+
+	return sub.ch, dropped, s.cancelFunc(func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		delete(s.addressSubs, id)
+	})
+}
+
+func (s *Subscriber) cancelFunc(f func()) CancelFunc {
+	var once sync.Once
+	return func() {
+		once.Do(f)
+	}
+}
+
+// NotifyBlock fans rb out to every block subscription registered with
+// fromSeq <= rb.Head.BkSeq. Intended to be called from
+// Visor.ExecuteSignedBlock after a block is executed, once that hook exists.
+func (s *Subscriber) NotifyBlock(rb ReadableBlock) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.blockSubs {
+		if rb.Head.BkSeq < sub.fromSeq {
+			continue
+		}
+		sendBlockOrDropOldest(sub.ch, rb, &sub.dropped)
+	}
+}
+
+// NotifyUnconfirmed fans txn out to every unconfirmed subscription. Intended
+// to be called from the unconfirmed pool's insert path, once that hook
+// exists.
+func (s *Subscriber) NotifyUnconfirmed(txn ReadableUnconfirmedTxn) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, sub := range s.unconfirmedSubs {
+		sendUnconfirmedOrDropOldest(sub.ch, txn, &sub.dropped)
+	}
+}
+
+// NotifyAddress fans an AddressEvent for txn out to every subscription
+// watching addr. Intended to be called from both the unconfirmed pool's
+// insert/remove paths and Visor.ExecuteSignedBlock, once per address the
+// transaction touches, once those hooks exist.
+func (s *Subscriber) NotifyAddress(addr cipher.Address, txn ReadableTransaction, dir Direction, balance wallet.Balance) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	event := AddressEvent{
+		Txn:       txn,
+		Direction: dir,
+		Balance:   balance,
+	}
+
+	for _, sub := range s.addressSubs {
+		if sub.addr != addr {
+			continue
+		}
+		sendAddressEventOrDropOldest(sub.ch, event, &sub.dropped)
+	}
+}
+
+// BlockSubscriberCount returns the number of active block subscriptions
+func (s *Subscriber) BlockSubscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.blockSubs)
+}
+
+// UnconfirmedSubscriberCount returns the number of active unconfirmed
+// transaction subscriptions
+func (s *Subscriber) UnconfirmedSubscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.unconfirmedSubs)
+}
+
+// AddressSubscriberCount returns the number of active address subscriptions
+func (s *Subscriber) AddressSubscriberCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.addressSubs)
+}
+
+// sendBlockOrDropOldest sends rb on ch without blocking. If ch is full, the
+// oldest queued block is discarded to make room and *dropped is incremented.
+func sendBlockOrDropOldest(ch chan ReadableBlock, rb ReadableBlock, dropped *uint64) {
+	for {
+		select {
+		case ch <- rb:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+			atomic.AddUint64(dropped, 1)
+		default:
+			// Another goroutine drained a slot between our send and receive
+			// attempts; loop around and try sending again.
+		}
+	}
+}
+
+// sendUnconfirmedOrDropOldest sends txn on ch without blocking, dropping the
+// oldest queued txn to make room if ch is full. See sendBlockOrDropOldest.
+func sendUnconfirmedOrDropOldest(ch chan ReadableUnconfirmedTxn, txn ReadableUnconfirmedTxn, dropped *uint64) {
+	for {
+		select {
+		case ch <- txn:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+			atomic.AddUint64(dropped, 1)
+		default:
+		}
+	}
+}
+
+// sendAddressEventOrDropOldest sends event on ch without blocking, dropping
+// the oldest queued event to make room if ch is full. See
+// sendBlockOrDropOldest.
+func sendAddressEventOrDropOldest(ch chan AddressEvent, event AddressEvent, dropped *uint64) {
+	for {
+		select {
+		case ch <- event:
+			return
+		default:
+		}
+
+		select {
+		case <-ch:
+			atomic.AddUint64(dropped, 1)
+		default:
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package visor
+
+import (
+	"github.com/skycoin/skycoin/src/cipher"
+	"github.com/skycoin/skycoin/src/cipher/encoder"
+	"github.com/skycoin/skycoin/src/coin"
+	"github.com/skycoin/skycoin/src/util/droplet"
+)
+
+// WithdrawalsActivationSeq is the block sequence at which withdrawals
+// (block-level credits not represented as signed transactions) become
+// active. Blocks before this height carry an empty WithdrawalsHash and
+// must not include any withdrawals, so that serialization of historical
+// blocks is unaffected by this feature. It defaults to the max uint64 so
+// that withdrawals stay dormant until a fork lowers it for a specific chain.
+var WithdrawalsActivationSeq uint64 = ^uint64(0)
+
+// Withdrawal represents a block-level credit that mints coins and hours to
+// an address as part of block processing, without a corresponding signed
+// transaction. This is analogous to EIP-4895's post-Shanghai validator
+// withdrawals, and is intended for uses such as a scheduled distribution
+// address rotation.
+type Withdrawal struct {
+	Index   uint64
+	Address cipher.Address
+	Coins   uint64
+	Hours   uint64
+}
+
+// Hash returns the hash of the withdrawal, for computing WithdrawalsHash
+func (w Withdrawal) Hash() cipher.SHA256 {
+	return cipher.SumSHA256(encoder.Serialize(w))
+}
+
+// WithdrawalsHash computes the Merkle root of a withdrawal list. It returns
+// the empty hash for an empty list, so that pre-fork blocks (which carry no
+// withdrawals) serialize with the same WithdrawalsHash as before this
+// feature existed.
+func WithdrawalsHash(ws []Withdrawal) cipher.SHA256 {
+	if len(ws) == 0 {
+		return cipher.SHA256{}
+	}
+
+	hashes := make([]cipher.SHA256, len(ws))
+	for i, w := range ws {
+		hashes[i] = w.Hash()
+	}
+
+	return cipher.Merkle(hashes)
+}
+
+// ReadableWithdrawal is the JSON-safe representation of a Withdrawal
+type ReadableWithdrawal struct {
+	Index   uint64 `json:"index"`
+	Address string `json:"address"`
+	Coins   string `json:"coins"`
+	Hours   uint64 `json:"hours"`
+}
+
+// NewReadableWithdrawal creates a ReadableWithdrawal
+func NewReadableWithdrawal(w Withdrawal) (*ReadableWithdrawal, error) {
+	coinStr, err := droplet.ToString(w.Coins)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReadableWithdrawal{
+		Index:   w.Index,
+		Address: w.Address.String(),
+		Coins:   coinStr,
+		Hours:   w.Hours,
+	}, nil
+}
+
+// NewReadableWithdrawals converts []Withdrawal to []ReadableWithdrawal
+func NewReadableWithdrawals(ws []Withdrawal) ([]ReadableWithdrawal, error) {
+	if len(ws) == 0 {
+		return nil, nil
+	}
+
+	rws := make([]ReadableWithdrawal, len(ws))
+	for i, w := range ws {
+		rw, err := NewReadableWithdrawal(w)
+		if err != nil {
+			return nil, err
+		}
+		rws[i] = *rw
+	}
+	return rws, nil
+}
+
+// WithdrawalsUxOuts converts a block's withdrawals into the unspent outputs
+// they mint, in the same shape a coinbase output would take, so that
+// callers building ReadableOutputSet.IncomingOutputs/HeadOutputs can fold
+// withdrawn coins in alongside ordinary transaction outputs.
+func WithdrawalsUxOuts(bkSeq uint64, head *coin.BlockHeader, ws []Withdrawal) coin.UxArray {
+	uxs := make(coin.UxArray, 0, len(ws))
+	for _, w := range ws {
+		uxs = append(uxs, coin.UxOut{
+			Head: coin.UxHead{
+				Time:  head.Time,
+				BkSeq: bkSeq,
+			},
+			Body: coin.UxBody{
+				SrcTransaction: head.Hash(),
+				Address:        w.Address,
+				Coins:          w.Coins,
+				Hours:          w.Hours,
+			},
+		})
+	}
+	return uxs
+}
+
+// WithdrawalScheduler decides what to mint as part of processing a given
+// block, e.g. a distribution address rotation
+type WithdrawalScheduler interface {
+	WithdrawalsForBlock(bkSeq uint64) ([]Withdrawal, error)
+}
+
+// ExecuteWithdrawals returns the withdrawals to credit at bkSeq by asking
+// scheduler, or nil if withdrawals are not yet active at this height. It is
+// intended to be called from Visor.ExecuteSignedBlock while building the
+// block at bkSeq, guarded by WithdrawalsActivationSeq so chains that have
+// not reached the activation height are unaffected; wiring that call site
+// in is left to a follow-up change.
+func ExecuteWithdrawals(bkSeq uint64, scheduler WithdrawalScheduler) ([]Withdrawal, error) {
+	if bkSeq < WithdrawalsActivationSeq {
+		return nil, nil
+	}
+	return scheduler.WithdrawalsForBlock(bkSeq)
+}